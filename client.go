@@ -0,0 +1,67 @@
+package httpt
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+)
+
+// codeFilterTransport wraps another http.RoundTripper and turns responses whose status
+// code is not allowed into a Go error, after fully draining and closing the original body
+// so the underlying connection can still be reused.
+type codeFilterTransport struct {
+	next  http.RoundTripper
+	allow func(code int) bool
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *codeFilterTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+
+	if t.allow(resp.StatusCode) {
+		return resp, nil
+	}
+
+	body, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	// A RoundTripper must not return both a response and an error, so don't return resp here.
+	return nil, fmt.Errorf("httpt: unexpected response status %d for %s %s: %s",
+		resp.StatusCode, req.Method, req.URL, string(body))
+}
+
+// wrapWithCodeFilter returns a copy of c whose transport rejects responses for which allow
+// returns false, falling back to http.DefaultTransport if c has no Transport set.
+func wrapWithCodeFilter(c *http.Client, allow func(code int) bool) *http.Client {
+	next := c.Transport
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	clone := *c
+	clone.Transport = &codeFilterTransport{next: next, allow: allow}
+	return &clone
+}
+
+// Response2xxOnly wraps c so that any response outside the 2xx range is converted into a Go
+// error, mirroring callers that treat anything but success as a failure. It composes
+// cleanly with Server.HTTPClient, since the underlying mocked transport still runs first.
+func Response2xxOnly(c *http.Client) *http.Client {
+	return wrapWithCodeFilter(c, func(code int) bool {
+		return code >= 200 && code < 300
+	})
+}
+
+// ResponseCodesOnly wraps c so that any response whose status code is not in allowed is
+// converted into a Go error. See Response2xxOnly for the common 2xx-only case.
+func ResponseCodesOnly(c *http.Client, allowed ...int) *http.Client {
+	set := make(map[int]bool, len(allowed))
+	for _, code := range allowed {
+		set[code] = true
+	}
+	return wrapWithCodeFilter(c, func(code int) bool {
+		return set[code]
+	})
+}
@@ -0,0 +1,58 @@
+package httpt
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResponse2xxOnly_OK(t *testing.T) {
+	r, err := http.NewRequest(string(GET), "/test/path", nil)
+	require.NoError(t, err)
+
+	s := NewRawServer()
+	s.Push(mockedRT)
+	c := Response2xxOnly(s.HTTPClient())
+
+	resp, err := c.Do(r)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestResponse2xxOnly_NonOK_Err(t *testing.T) {
+	r, err := http.NewRequest(string(GET), "/test/path", nil)
+	require.NoError(t, err)
+
+	s := NewRawServer()
+	s.Push(specificRT) // Returns 201, which is still within 2xx.
+	c := Response2xxOnly(s.HTTPClient())
+	_, err = c.Do(r)
+	require.NoError(t, err)
+
+	s2 := NewRawServer()
+	s2.On(GET, "/test/path").Push(func(_ *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusInternalServerError, Body: http.NoBody}, nil
+	})
+	c2 := Response2xxOnly(s2.HTTPClient())
+	r2, err := http.NewRequest(string(GET), "/test/path", nil)
+	require.NoError(t, err)
+	_, err = c2.Do(r2)
+	require.Error(t, err)
+}
+
+func TestResponseCodesOnly_OK(t *testing.T) {
+	r, err := http.NewRequest(string(GET), "/test/path", nil)
+	require.NoError(t, err)
+
+	s := NewRawServer()
+	s.On(GET, "/test/path").Push(func(_ *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusNotFound, Body: http.NoBody}, nil
+	})
+	c := ResponseCodesOnly(s.HTTPClient(), http.StatusOK, http.StatusNotFound)
+
+	resp, err := c.Do(r)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
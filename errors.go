@@ -0,0 +1,110 @@
+package httpt
+
+import "fmt"
+
+// NotMockedError is returned (or used to fail the test, via NotMockedFunc) when no queued
+// entry matches an incoming request.
+type NotMockedError struct {
+	Method Method
+	Path   string
+
+	// Expected lists the still-expected entries at the time of the failed match. Use
+	// tripEntry.String() to format an entry as [METHOD]path.
+	Expected []tripEntry
+
+	// Suggestion is a short "did you mean ...?" hint based on Levenshtein distance to the
+	// queued paths, or a method-mismatch diagnostic when a queued entry exists for the same
+	// path under a different method. Empty if there was nothing helpful to suggest.
+	Suggestion string
+}
+
+// Error implements the error interface.
+func (e *NotMockedError) Error() string {
+	msg := fmt.Sprintf("httpt.Server: request not mocked for %s:%s", e.Method, e.Path)
+	if e.Suggestion != "" {
+		msg += ". " + e.Suggestion
+	}
+	if len(e.Expected) > 0 {
+		formatted := make([]string, len(e.Expected))
+		for i, ent := range e.Expected {
+			formatted[i] = ent.String()
+		}
+		msg += fmt.Sprintf(" Still expected: %v", formatted)
+	}
+	return msg
+}
+
+// newNotMockedError builds a NotMockedError for a failed match of method/path against queue,
+// computing a method-mismatch or nearest-path suggestion where possible.
+func newNotMockedError(queue []tripEntry, method Method, path string) *NotMockedError {
+	expected := make([]tripEntry, len(queue))
+	copy(expected, queue)
+	err := &NotMockedError{Method: method, Path: path, Expected: expected}
+
+	for _, e := range queue {
+		if e.matcher != nil || e.path == AnyPath {
+			continue
+		}
+		if e.path == path && e.method != method && e.method != ANY {
+			err.Suggestion = fmt.Sprintf("method mismatch: %s is mocked for this path, not %s", e.method, method)
+			return err
+		}
+	}
+
+	nearest := ""
+	nearestDist := -1
+	for _, e := range queue {
+		if e.matcher != nil || e.path == AnyPath {
+			continue
+		}
+		d := levenshtein(path, e.path)
+		if nearestDist == -1 || d < nearestDist {
+			nearestDist = d
+			nearest = e.path
+		}
+	}
+	if nearest != "" {
+		err.Suggestion = fmt.Sprintf("did you mean %q?", nearest)
+	}
+
+	return err
+}
+
+// levenshtein returns the Levenshtein edit distance between a and b.
+func levenshtein(a, b string) int {
+	if a == b {
+		return 0
+	}
+
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min3(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(rb)]
+}
+
+func min3(a, b, c int) int {
+	m := a
+	if b < m {
+		m = b
+	}
+	if c < m {
+		m = c
+	}
+	return m
+}
@@ -0,0 +1,51 @@
+package httpt
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_NotMocked_MethodMismatch(t *testing.T) {
+	r, err := http.NewRequest(string(GET), "/test/path", nil)
+	require.NoError(t, err)
+
+	s := NewRawServer()
+	s.On(POST, "/test/path").Push(mockedRT)
+	_, err = s.HTTPClient().Do(r)
+	require.Error(t, err)
+
+	urlErr, ok := err.(*url.Error)
+	require.True(t, ok)
+	notMocked, ok := urlErr.Err.(*NotMockedError)
+	require.True(t, ok)
+	assert.Equal(t, GET, notMocked.Method)
+	assert.Equal(t, "/test/path", notMocked.Path)
+	assert.Contains(t, notMocked.Suggestion, "method mismatch")
+	require.Len(t, notMocked.Expected, 1)
+	assert.Equal(t, POST, notMocked.Expected[0].method)
+	assert.Equal(t, "/test/path", notMocked.Expected[0].path)
+	assert.Equal(t, "[POST]/test/path", notMocked.Expected[0].String())
+	assert.Contains(t, notMocked.Error(), "method mismatch")
+	assert.Contains(t, notMocked.Error(), "Still expected: [[POST]/test/path]")
+}
+
+func TestServer_NotMocked_NearestPathSuggestion(t *testing.T) {
+	r, err := http.NewRequest(string(GET), "/test/path", nil)
+	require.NoError(t, err)
+
+	s := NewRawServer()
+	s.On(GET, "/test/paht").Push(mockedRT)
+	_, err = s.HTTPClient().Do(r)
+	require.Error(t, err)
+
+	urlErr, ok := err.(*url.Error)
+	require.True(t, ok)
+	notMocked, ok := urlErr.Err.(*NotMockedError)
+	require.True(t, ok)
+	assert.Contains(t, notMocked.Suggestion, `did you mean "/test/paht"?`)
+	assert.Contains(t, notMocked.Error(), `did you mean "/test/paht"?`)
+}
@@ -0,0 +1,247 @@
+package httpt
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// FixtureSet is the root of a fixtures file loaded by LoadFixtures or written by DumpFixtures.
+type FixtureSet struct {
+	Fixtures []Fixture `yaml:"fixtures" json:"fixtures"`
+}
+
+// Fixture describes one expected round trip: what to match, and what to respond with.
+type Fixture struct {
+	Method string `yaml:"method" json:"method"`
+
+	// Path is matched literally, unless prefixed with "regex:", in which case the rest is
+	// compiled as a regular expression.
+	Path string `yaml:"path" json:"path"`
+
+	Match    *FixtureMatch   `yaml:"match,omitempty" json:"match,omitempty"`
+	Response FixtureResponse `yaml:"response" json:"response"`
+}
+
+// FixtureMatch holds the extra matching criteria beyond method and path.
+type FixtureMatch struct {
+	Query    map[string]string `yaml:"query,omitempty" json:"query,omitempty"`
+	Headers  map[string]string `yaml:"headers,omitempty" json:"headers,omitempty"`
+	BodyJSON string            `yaml:"bodyJSON,omitempty" json:"bodyJSON,omitempty"`
+}
+
+// FixtureResponse describes the response for a matched Fixture. Error, if set, simulates a
+// connection failure instead of returning a response. Status defaults to 200.
+type FixtureResponse struct {
+	Status     int               `yaml:"status,omitempty" json:"status,omitempty"`
+	Headers    map[string]string `yaml:"headers,omitempty" json:"headers,omitempty"`
+	BodyString string            `yaml:"bodyString,omitempty" json:"bodyString,omitempty"`
+	BodyJSON   interface{}       `yaml:"bodyJSON,omitempty" json:"bodyJSON,omitempty"`
+	BodyFile   string            `yaml:"bodyFile,omitempty" json:"bodyFile,omitempty"`
+	Error      string            `yaml:"error,omitempty" json:"error,omitempty"`
+}
+
+// LoadFixtures reads a YAML or JSON fixtures file (JSON is picked for a ".json" path,
+// YAML otherwise) and pushes its fixtures onto s in order, via On or OnMatch depending on
+// whether a fixture needs more than an exact method/path match.
+func LoadFixtures(s *Server, path string) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var set FixtureSet
+	if strings.HasSuffix(path, ".json") {
+		err = json.Unmarshal(data, &set)
+	} else {
+		err = yaml.Unmarshal(data, &set)
+	}
+	if err != nil {
+		return fmt.Errorf("httpt: failed to parse fixtures file %s: %v", path, err)
+	}
+
+	for i, f := range set.Fixtures {
+		if err := pushFixture(s, f); err != nil {
+			return fmt.Errorf("httpt: failed to load fixture %d in %s: %v", i, path, err)
+		}
+	}
+	return nil
+}
+
+// DumpFixtures serializes every call recorded so far by s (see Server.Calls) as a YAML
+// FixtureSet, so a test run against a real dependency can be recorded once and replayed
+// with LoadFixtures from then on. The dumped responses default to a 200 status with the
+// request's own body echoed back; edit the file to fill in the real expected responses.
+func DumpFixtures(s *Server, w io.Writer) error {
+	var set FixtureSet
+	for _, c := range s.Calls() {
+		f := Fixture{
+			Method: string(c.Method),
+			Path:   c.URL.Path,
+			Match:  fixtureMatchFromCall(c),
+			Response: FixtureResponse{
+				Status:     http.StatusOK,
+				BodyString: string(c.Body),
+			},
+		}
+		set.Fixtures = append(set.Fixtures, f)
+	}
+
+	data, err := yaml.Marshal(set)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// fixtureMatchFromCall builds the Match block that reproduces c's query and headers, so a
+// dumped fixture round-trips with the same specificity it was recorded with. Returns nil if
+// c had no query or headers worth matching on.
+func fixtureMatchFromCall(c RecordedCall) *FixtureMatch {
+	var m FixtureMatch
+
+	if q := c.URL.Query(); len(q) > 0 {
+		m.Query = make(map[string]string, len(q))
+		for k, v := range q {
+			if len(v) > 0 {
+				m.Query[k] = v[0]
+			}
+		}
+	}
+
+	if len(c.Header) > 0 {
+		m.Headers = make(map[string]string, len(c.Header))
+		for k, v := range c.Header {
+			if len(v) > 0 {
+				m.Headers[k] = v[0]
+			}
+		}
+	}
+
+	if len(m.Query) == 0 && len(m.Headers) == 0 {
+		return nil
+	}
+	return &m
+}
+
+// pushFixture pushes a single Fixture onto s, via On for a plain method/path fixture or
+// OnMatch once a regex path or a Match block asks for more specific matching.
+func pushFixture(s *Server, f Fixture) error {
+	trip, err := fixtureRoundTrip(f.Response)
+	if err != nil {
+		return err
+	}
+
+	method := Method(strings.ToUpper(f.Method))
+	regexPath := strings.HasPrefix(f.Path, "regex:")
+
+	if f.Match == nil && !regexPath {
+		s.On(method, f.Path).Push(trip)
+		return nil
+	}
+
+	m := Matcher{Method: method}
+	if regexPath {
+		re, err := regexp.Compile(strings.TrimPrefix(f.Path, "regex:"))
+		if err != nil {
+			return err
+		}
+		m.PathRegexp = re
+	} else if f.Path != "" {
+		path := f.Path
+		m.Predicate = func(req *http.Request) bool {
+			return getPathOnly(req) == path
+		}
+	}
+
+	if f.Match != nil {
+		if len(f.Match.Query) > 0 {
+			q := make(url.Values, len(f.Match.Query))
+			for k, v := range f.Match.Query {
+				q.Set(k, v)
+			}
+			m.Query = q
+		}
+		if len(f.Match.Headers) > 0 {
+			h := make(http.Header, len(f.Match.Headers))
+			for k, v := range f.Match.Headers {
+				h.Set(k, v)
+			}
+			m.Headers = h
+		}
+		if f.Match.BodyJSON != "" {
+			expected := []byte(f.Match.BodyJSON)
+			m.BodyMatcher = func(body []byte) bool {
+				return jsonEqual(body, expected)
+			}
+		}
+	}
+
+	s.OnMatch(m).Push(trip)
+	return nil
+}
+
+// fixtureRoundTrip builds the round trip function described by a FixtureResponse.
+func fixtureRoundTrip(f FixtureResponse) (RoundTripFunc, error) {
+	if f.Error != "" {
+		return FailureFunc(errors.New(f.Error)), nil
+	}
+
+	status := f.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	header := make(http.Header)
+	var body []byte
+	switch {
+	case f.BodyFile != "":
+		data, err := ioutil.ReadFile(f.BodyFile)
+		if err != nil {
+			return nil, err
+		}
+		body = data
+	case f.BodyJSON != nil:
+		data, err := json.Marshal(f.BodyJSON)
+		if err != nil {
+			return nil, err
+		}
+		body = data
+		header.Set("Content-Type", "application/json")
+	case f.BodyString != "":
+		body = []byte(f.BodyString)
+	}
+
+	for k, v := range f.Headers {
+		header.Set(k, v)
+	}
+
+	return func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: status,
+			Header:     header,
+			Body:       ioutil.NopCloser(bytes.NewReader(body)),
+			Request:    req,
+		}, nil
+	}, nil
+}
+
+// jsonEqual reports whether a and b are equal as JSON documents, ignoring formatting.
+func jsonEqual(a, b []byte) bool {
+	var va, vb interface{}
+	if json.Unmarshal(a, &va) != nil || json.Unmarshal(b, &vb) != nil {
+		return false
+	}
+	return reflect.DeepEqual(va, vb)
+}
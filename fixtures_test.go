@@ -0,0 +1,105 @@
+package httpt
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadFixtures_JSON_OK(t *testing.T) {
+	dir, err := ioutil.TempDir("", "httpt-fixtures")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "fixtures.json")
+	err = ioutil.WriteFile(path, []byte(`{
+		"fixtures": [
+			{"method": "GET", "path": "/users/1", "response": {"status": 200, "bodyString": "user-1"}},
+			{"method": "GET", "path": "regex:^/users/\\d+$", "response": {"status": 404, "bodyString": "not found"}}
+		]
+	}`), 0644)
+	require.NoError(t, err)
+
+	s := NewRawServer()
+	require.NoError(t, LoadFixtures(s, path))
+
+	// The regex fixture is pushed via OnMatch and outscores the literal one, so it would also
+	// take /users/1 while queued; /users/2 goes first to use it up.
+	r2, err := http.NewRequest(string(GET), "/users/2", nil)
+	require.NoError(t, err)
+	resp2, err := s.HTTPClient().Do(r2)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp2.StatusCode)
+
+	r, err := http.NewRequest(string(GET), "/users/1", nil)
+	require.NoError(t, err)
+	resp, err := s.HTTPClient().Do(r)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	body, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "user-1", string(body))
+}
+
+func TestLoadFixtures_Match_OK(t *testing.T) {
+	dir, err := ioutil.TempDir("", "httpt-fixtures")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "fixtures.json")
+	err = ioutil.WriteFile(path, []byte(`{
+		"fixtures": [
+			{
+				"method": "POST",
+				"path": "/users",
+				"match": {
+					"query": {"source": "signup"},
+					"headers": {"X-Test": "1"},
+					"bodyJSON": "{\"name\": \"gopher\"}"
+				},
+				"response": {"status": 201, "bodyString": "created"}
+			}
+		]
+	}`), 0644)
+	require.NoError(t, err)
+
+	s := NewRawServer()
+	require.NoError(t, LoadFixtures(s, path))
+
+	r, err := http.NewRequest(string(POST), "/users?source=signup", bytes.NewBufferString(`{"name":"gopher"}`))
+	require.NoError(t, err)
+	r.Header.Set("X-Test", "1")
+	resp, err := s.HTTPClient().Do(r)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	r2, err := http.NewRequest(string(POST), "/users?source=signup", bytes.NewBufferString(`{"name":"other"}`))
+	require.NoError(t, err)
+	r2.Header.Set("X-Test", "1")
+	_, err = s.HTTPClient().Do(r2)
+	require.Error(t, err)
+}
+
+func TestDumpFixtures_OK(t *testing.T) {
+	s := NewRawServer()
+	s.Push(mockedRT)
+
+	r, err := http.NewRequest(string(GET), "/test/path?q=gophers", bytes.NewBufferString("hello"))
+	require.NoError(t, err)
+	r.Header.Set("X-Test", "1")
+	_, err = s.HTTPClient().Do(r)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, DumpFixtures(s, &buf))
+	assert.Contains(t, buf.String(), "/test/path")
+	assert.Contains(t, buf.String(), "hello")
+	assert.Contains(t, buf.String(), "gophers")
+	assert.Contains(t, buf.String(), "X-Test")
+}
@@ -0,0 +1,108 @@
+package httpt
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"reflect"
+	"regexp"
+)
+
+// Matcher provides fine-grained, scored request matching for Server.OnMatch,
+// as an alternative to the exact method+path matching done by On. In addition
+// to Method, a Matcher can match on a path regexp, required query values,
+// required header values, a raw body predicate and/or an arbitrary predicate
+// over the full request. Any zero-valued field is not taken into account.
+//
+// When several queued entries could match the same request, the one with the
+// highest number of matched criteria (i.e. the most specific) wins; entries
+// of equal specificity are tried in FIFO order, same as plain On entries.
+type Matcher struct {
+	Method      Method
+	PathRegexp  *regexp.Regexp
+	Query       url.Values
+	Headers     http.Header
+	BodyMatcher func([]byte) bool
+	Predicate   func(*http.Request) bool
+}
+
+// score reports how many of m's criteria matched req, or ok=false if any
+// criterion that was set did not match.
+func (m *Matcher) score(req *http.Request, path string) (score int, ok bool) {
+	if m.Method != "" && m.Method != ANY {
+		if m.Method != Method(req.Method) {
+			return 0, false
+		}
+		score++
+	}
+
+	if m.PathRegexp != nil {
+		if !m.PathRegexp.MatchString(path) {
+			return 0, false
+		}
+		score++
+	}
+
+	if len(m.Query) > 0 {
+		q := req.URL.Query()
+		for k, vs := range m.Query {
+			if !reflect.DeepEqual(q[k], vs) {
+				return 0, false
+			}
+		}
+		score++
+	}
+
+	if len(m.Headers) > 0 {
+		for k, vs := range m.Headers {
+			if !reflect.DeepEqual(req.Header[k], vs) {
+				return 0, false
+			}
+		}
+		score++
+	}
+
+	if m.BodyMatcher != nil {
+		body, err := peekBody(req)
+		if err != nil || !m.BodyMatcher(body) {
+			return 0, false
+		}
+		score++
+	}
+
+	if m.Predicate != nil {
+		if !m.Predicate(req) {
+			return 0, false
+		}
+		score++
+	}
+
+	return score, true
+}
+
+// peekBody reads req.Body in full and puts it back so that whatever reads it
+// next (e.g. the matched RoundTripFunc) still sees it from the start.
+func peekBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil {
+		return nil, nil
+	}
+
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
+
+// OnMatch specifies a Matcher for mocked round trip function, allowing
+// matching beyond the exact method/path pairs supported by On.
+// Example usage:
+//    server.OnMatch(httpt.Matcher{
+//        Method:     httpt.GET,
+//        PathRegexp: regexp.MustCompile(`^/users/\d+$`),
+//    }).Push(<any round trip function>)
+func (t *tripBuilder) OnMatch(m Matcher) *tripPusher {
+	return newMatchTripPusher(t.engine, m)
+}
@@ -0,0 +1,146 @@
+package httpt
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func specificRT(_ *http.Request) (*http.Response, error) {
+	return &http.Response{
+		StatusCode: http.StatusCreated,
+		Body:       ioutil.NopCloser(bytes.NewBufferString("specific")),
+	}, nil
+}
+
+func TestServer_OnMatch_PathRegexp_OK(t *testing.T) {
+	r, err := http.NewRequest(string(GET), "/users/42", nil)
+	require.NoError(t, err)
+
+	s := NewRawServer()
+	s.OnMatch(Matcher{Method: GET, PathRegexp: regexp.MustCompile(`^/users/\d+$`)}).Push(mockedRT)
+	resp, err := s.HTTPClient().Do(r)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, 0, s.Len())
+}
+
+func TestServer_OnMatch_PathRegexp_NoMatch_Err(t *testing.T) {
+	r, err := http.NewRequest(string(GET), "/groups/42", nil)
+	require.NoError(t, err)
+
+	s := NewRawServer()
+	s.OnMatch(Matcher{Method: GET, PathRegexp: regexp.MustCompile(`^/users/\d+$`)}).Push(mockedRT)
+	_, err = s.HTTPClient().Do(r)
+	require.Error(t, err)
+
+	assert.Equal(t, 1, s.Len())
+}
+
+func TestServer_OnMatch_Query_OK(t *testing.T) {
+	r, err := http.NewRequest(string(GET), "/search?q=gophers", nil)
+	require.NoError(t, err)
+
+	s := NewRawServer()
+	s.OnMatch(Matcher{Query: map[string][]string{"q": {"gophers"}}}).Push(mockedRT)
+	resp, err := s.HTTPClient().Do(r)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestServer_OnMatch_Predicate_OK(t *testing.T) {
+	r, err := http.NewRequest(string(GET), "/test/path", nil)
+	require.NoError(t, err)
+	r.Header.Set("X-Test", "1")
+
+	s := NewRawServer()
+	s.OnMatch(Matcher{Predicate: func(req *http.Request) bool {
+		return req.Header.Get("X-Test") == "1"
+	}}).Push(mockedRT)
+	resp, err := s.HTTPClient().Do(r)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestServer_OnMatch_Headers_OK(t *testing.T) {
+	r, err := http.NewRequest(string(GET), "/test/path", nil)
+	require.NoError(t, err)
+	r.Header.Set("X-Test", "1")
+
+	s := NewRawServer()
+	s.OnMatch(Matcher{Headers: http.Header{"X-Test": {"1"}}}).Push(mockedRT)
+	resp, err := s.HTTPClient().Do(r)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestServer_OnMatch_Headers_Mismatch_Err(t *testing.T) {
+	r, err := http.NewRequest(string(GET), "/test/path", nil)
+	require.NoError(t, err)
+	r.Header.Set("X-Test", "2")
+
+	s := NewRawServer()
+	s.OnMatch(Matcher{Headers: http.Header{"X-Test": {"1"}}}).Push(mockedRT)
+	_, err = s.HTTPClient().Do(r)
+	require.Error(t, err)
+
+	assert.Equal(t, 1, s.Len())
+}
+
+func TestServer_OnMatch_BodyMatcher_OK(t *testing.T) {
+	r, err := http.NewRequest(string(POST), "/test/path", bytes.NewBufferString("hello"))
+	require.NoError(t, err)
+
+	s := NewRawServer()
+	s.OnMatch(Matcher{BodyMatcher: func(body []byte) bool {
+		return string(body) == "hello"
+	}}).Push(mockedRT)
+	resp, err := s.HTTPClient().Do(r)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// The recorder already drained and replaced req.Body before the matcher ran;
+	// the matcher must still see the full body, and so must the recorded call.
+	require.Len(t, s.Calls(), 1)
+	assert.Equal(t, []byte("hello"), s.Calls()[0].Body)
+}
+
+func TestServer_OnMatch_BodyMatcher_Mismatch_Err(t *testing.T) {
+	r, err := http.NewRequest(string(POST), "/test/path", bytes.NewBufferString("hello"))
+	require.NoError(t, err)
+
+	s := NewRawServer()
+	s.OnMatch(Matcher{BodyMatcher: func(body []byte) bool {
+		return string(body) == "goodbye"
+	}}).Push(mockedRT)
+	_, err = s.HTTPClient().Do(r)
+	require.Error(t, err)
+
+	assert.Equal(t, 1, s.Len())
+}
+
+func TestServer_OnMatch_MostSpecificWins(t *testing.T) {
+	r, err := http.NewRequest(string(GET), "/users/42", nil)
+	require.NoError(t, err)
+
+	s := NewRawServer()
+	s.Push(mockedRT) // Least specific: matches anything.
+	s.OnMatch(Matcher{Method: GET, PathRegexp: regexp.MustCompile(`^/users/\d+$`)}).Push(specificRT)
+
+	resp, err := s.HTTPClient().Do(r)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	// The less specific entry is still queued.
+	assert.Equal(t, 1, s.Len())
+}
@@ -0,0 +1,110 @@
+package httpt
+
+import (
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_Persist_OK(t *testing.T) {
+	r, err := http.NewRequest(string(GET), "/healthz", nil)
+	require.NoError(t, err)
+
+	s := NewRawServer()
+	s.On(GET, "/healthz").Persist(mockedRT)
+
+	for i := 0; i < 5; i++ {
+		resp, err := s.HTTPClient().Do(r)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	}
+
+	// Persistent entries are never "used up" and don't count towards Len.
+	assert.Equal(t, 0, s.Len())
+}
+
+func TestServer_Times_OK(t *testing.T) {
+	r, err := http.NewRequest(string(GET), "/poll", nil)
+	require.NoError(t, err)
+
+	s := NewRawServer()
+	s.On(GET, "/poll").Times(2, mockedRT)
+
+	for i := 0; i < 2; i++ {
+		resp, err := s.HTTPClient().Do(r)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	}
+
+	// Exhausted, so a third call fails.
+	_, err = s.HTTPClient().Do(r)
+	require.Error(t, err)
+}
+
+func TestServer_Times_ZeroOrNegative_NeverMatches(t *testing.T) {
+	r, err := http.NewRequest(string(GET), "/poll", nil)
+	require.NoError(t, err)
+
+	s := NewRawServer()
+	s.On(GET, "/poll").Times(0, mockedRT)
+	_, err = s.HTTPClient().Do(r)
+	require.Error(t, err)
+
+	s.On(GET, "/poll").Times(-1, mockedRT)
+	_, err = s.HTTPClient().Do(r)
+	require.Error(t, err)
+}
+
+func TestServer_Persist_OverriddenByOneShot(t *testing.T) {
+	r, err := http.NewRequest(string(GET), "/healthz", nil)
+	require.NoError(t, err)
+
+	s := NewRawServer()
+	s.On(GET, "/healthz").Persist(mockedRT)
+	s.On(GET, "/healthz").Push(specificRT)
+
+	// The one-shot entry wins first, even though it was pushed after the persistent one.
+	resp, err := s.HTTPClient().Do(r)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	// Subsequent calls fall back to the persistent mock.
+	resp, err = s.HTTPClient().Do(r)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestServer_Persist_ConcurrentCallsDontRace(t *testing.T) {
+	s := NewRawServer()
+	s.On(GET, "/healthz").Persist(mockedRT)
+
+	const n = 100
+	var wg sync.WaitGroup
+	wg.Add(n)
+	for i := 0; i < n; i++ {
+		go func() {
+			defer wg.Done()
+			r, err := http.NewRequest(string(GET), "/healthz", nil)
+			assert.NoError(t, err)
+			resp, err := s.HTTPClient().Do(r)
+			assert.NoError(t, err)
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+		}()
+	}
+	wg.Wait()
+}
+
+func TestServer_Reset_ClearsPersistent(t *testing.T) {
+	r, err := http.NewRequest(string(GET), "/healthz", nil)
+	require.NoError(t, err)
+
+	s := NewRawServer()
+	s.On(GET, "/healthz").Persist(mockedRT)
+	s.Reset()
+
+	_, err = s.HTTPClient().Do(r)
+	require.Error(t, err)
+}
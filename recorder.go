@@ -0,0 +1,115 @@
+package httpt
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sync"
+)
+
+// RecordedCall is a snapshot of an incoming *http.Request, captured by Server as it
+// passes through the mocked transport. Use it to assert on what the code under test
+// actually sent, e.g. via s.Calls(), s.CallsFor(method, path) or s.LastCall().
+type RecordedCall struct {
+	Method Method
+	URL    *url.URL
+	Header http.Header
+
+	// Body is the full request body, capped at the Server's MaxBodyBytes if set.
+	Body []byte
+}
+
+// DecodeJSON decodes the call's body as JSON into v.
+func (c *RecordedCall) DecodeJSON(v interface{}) error {
+	return json.Unmarshal(c.Body, v)
+}
+
+// FormValues parses the call's body as a URL-encoded form, e.g. the body of a
+// "application/x-www-form-urlencoded" POST request.
+func (c *RecordedCall) FormValues() (url.Values, error) {
+	return url.ParseQuery(string(c.Body))
+}
+
+// recorder captures every request that passes through a Server's transport. It is safe
+// for concurrent use, since a Server's mocked transport may be hit from multiple
+// goroutines at once (e.g. a component polling a persisted mock in the background).
+type recorder struct {
+	mu    sync.Mutex
+	calls []RecordedCall
+}
+
+// record buffers req's body and appends a RecordedCall for it, while leaving req.Body
+// readable from the start for the RoundTripFunc that is about to be matched. maxBodyBytes
+// caps how much of the body is kept in the RecordedCall (0 means unlimited); the full body
+// is still passed on to the RoundTripFunc regardless of the cap. Note that the full body is
+// always read into memory first, so maxBodyBytes does not bound peak memory usage.
+func (rec *recorder) record(req *http.Request, maxBodyBytes int64) error {
+	u := *req.URL
+	call := RecordedCall{
+		Method: Method(req.Method),
+		URL:    &u,
+		Header: req.Header.Clone(),
+	}
+
+	if req.Body != nil {
+		var buf bytes.Buffer
+		if _, err := ioutil.ReadAll(io.TeeReader(req.Body, &buf)); err != nil {
+			return err
+		}
+		req.Body = ioutil.NopCloser(bytes.NewReader(buf.Bytes()))
+
+		body := buf.Bytes()
+		if maxBodyBytes > 0 && int64(len(body)) > maxBodyBytes {
+			body = body[:maxBodyBytes]
+		}
+		call.Body = body
+	}
+
+	rec.mu.Lock()
+	rec.calls = append(rec.calls, call)
+	rec.mu.Unlock()
+	return nil
+}
+
+// snapshot returns a copy of the calls recorded so far, safe to range over without holding
+// rec.mu.
+func (rec *recorder) snapshot() []RecordedCall {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+
+	out := make([]RecordedCall, len(rec.calls))
+	copy(out, rec.calls)
+	return out
+}
+
+// Calls returns every request recorded so far, in the order they arrived.
+func (s *Server) Calls() []RecordedCall {
+	return s.recorder.snapshot()
+}
+
+// CallsFor returns the recorded calls matching method and path. Use ANY to match any method.
+func (s *Server) CallsFor(method Method, path string) []RecordedCall {
+	var out []RecordedCall
+	for _, c := range s.recorder.snapshot() {
+		if method != ANY && c.Method != method {
+			continue
+		}
+		if c.URL.Path != path {
+			continue
+		}
+		out = append(out, c)
+	}
+	return out
+}
+
+// LastCall returns the most recently recorded call, or nil if none were recorded yet.
+func (s *Server) LastCall() *RecordedCall {
+	calls := s.recorder.snapshot()
+	if len(calls) == 0 {
+		return nil
+	}
+	return &calls[len(calls)-1]
+}
@@ -0,0 +1,113 @@
+package httpt
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_Calls_OK(t *testing.T) {
+	s := NewRawServer()
+	s.On(GET, "/test/path").Persist(mockedRT)
+
+	r, err := http.NewRequest(string(GET), "/test/path?a=1", bytes.NewBufferString(`{"foo":"bar"}`))
+	require.NoError(t, err)
+
+	resp, err := s.HTTPClient().Do(r)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	require.Len(t, s.Calls(), 1)
+	call := s.LastCall()
+	require.NotNil(t, call)
+	assert.Equal(t, GET, call.Method)
+	assert.Equal(t, "/test/path", call.URL.Path)
+
+	var decoded struct {
+		Foo string `json:"foo"`
+	}
+	require.NoError(t, call.DecodeJSON(&decoded))
+	assert.Equal(t, "bar", decoded.Foo)
+}
+
+func TestServer_CallsFor_OK(t *testing.T) {
+	s := NewRawServer()
+	s.Push(mockedRT)
+	s.Push(mockedRT)
+
+	r1, err := http.NewRequest(string(GET), "/a", nil)
+	require.NoError(t, err)
+	r2, err := http.NewRequest(string(POST), "/b", nil)
+	require.NoError(t, err)
+
+	_, err = s.HTTPClient().Do(r1)
+	require.NoError(t, err)
+	_, err = s.HTTPClient().Do(r2)
+	require.NoError(t, err)
+
+	assert.Len(t, s.CallsFor(GET, "/a"), 1)
+	assert.Len(t, s.CallsFor(POST, "/b"), 1)
+	assert.Len(t, s.CallsFor(GET, "/b"), 0)
+}
+
+func TestServer_Calls_FormValues_OK(t *testing.T) {
+	s := NewRawServer()
+	s.Push(mockedRT)
+
+	r, err := http.NewRequest(string(POST), "/form", bytes.NewBufferString("a=1&b=2"))
+	require.NoError(t, err)
+
+	_, err = s.HTTPClient().Do(r)
+	require.NoError(t, err)
+
+	values, err := s.LastCall().FormValues()
+	require.NoError(t, err)
+	assert.Equal(t, url.Values{"a": {"1"}, "b": {"2"}}, values)
+}
+
+func TestServer_Calls_SurviveLaterMutationOfSharedRequest(t *testing.T) {
+	s := NewRawServer()
+	s.On(GET, "/orders").Persist(mockedRT)
+
+	r, err := http.NewRequest(string(GET), "/orders", nil)
+	require.NoError(t, err)
+
+	_, err = s.HTTPClient().Do(r)
+	require.NoError(t, err)
+
+	// A caller reusing the same *http.Request across several Do calls (as this test
+	// does) may mutate r.URL in place before the next call; the already-recorded
+	// call must not alias r.URL and change retroactively.
+	r.URL.Path = "/mutated-after-the-fact"
+	s.On(GET, "/mutated-after-the-fact").Push(mockedRT)
+	_, err = s.HTTPClient().Do(r)
+	require.NoError(t, err)
+
+	assert.Equal(t, "/orders", s.Calls()[0].URL.Path)
+	assert.Equal(t, "/mutated-after-the-fact", s.Calls()[1].URL.Path)
+}
+
+func TestServer_MaxBodyBytes_CapsRecordedBodyOnly(t *testing.T) {
+	s := NewRawServer()
+	s.MaxBodyBytes = 3
+
+	var seenBody []byte
+	s.Push(func(r *http.Request) (*http.Response, error) {
+		seenBody, _ = ioutil.ReadAll(r.Body)
+		return mockedRT(r)
+	})
+
+	r, err := http.NewRequest(string(POST), "/upload", bytes.NewBufferString("hello world"))
+	require.NoError(t, err)
+
+	_, err = s.HTTPClient().Do(r)
+	require.NoError(t, err)
+
+	assert.Equal(t, []byte("hel"), s.LastCall().Body)
+	assert.Equal(t, []byte("hello world"), seenBody)
+}
@@ -0,0 +1,196 @@
+package rt
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// ResponseBuilder fluently builds a round trip function for a single response.
+// Example usage:
+//    rt.Response().Status(201).Header("X-Foo", "bar").JSON(v).Build()
+type ResponseBuilder struct {
+	status int
+	header http.Header
+	body   []byte
+	err    error
+}
+
+// Response starts a ResponseBuilder, defaulting to a 200 OK with an empty body.
+func Response() *ResponseBuilder {
+	return &ResponseBuilder{
+		status: http.StatusOK,
+		header: make(http.Header),
+	}
+}
+
+// Status sets the response status code.
+func (b *ResponseBuilder) Status(code int) *ResponseBuilder {
+	b.status = code
+	return b
+}
+
+// Header sets a response header.
+func (b *ResponseBuilder) Header(key, value string) *ResponseBuilder {
+	b.header.Set(key, value)
+	return b
+}
+
+// String sets the response body to msg.
+func (b *ResponseBuilder) String(msg string) *ResponseBuilder {
+	b.body = []byte(msg)
+	return b
+}
+
+// JSON marshals v as the response body and sets the JSON content type header.
+func (b *ResponseBuilder) JSON(v interface{}) *ResponseBuilder {
+	body, err := json.Marshal(v)
+	if err != nil {
+		b.err = err
+		return b
+	}
+	b.body = body
+	b.header.Set("Content-Type", "application/json")
+	return b
+}
+
+// Build returns the round trip function for the response assembled so far.
+func (b *ResponseBuilder) Build() func(*http.Request) (*http.Response, error) {
+	return func(req *http.Request) (*http.Response, error) {
+		if b.err != nil {
+			return nil, b.err
+		}
+
+		// Clone so concurrent/repeated calls don't share (and race on) the same map, and so
+		// a caller mutating a previously-returned Header can't affect later responses.
+		header := b.header.Clone()
+		header.Set("Content-Length", strconv.Itoa(len(b.body)))
+		return &http.Response{
+			StatusCode:    b.status,
+			Header:        header,
+			Body:          ioutil.NopCloser(bytes.NewReader(b.body)),
+			ContentLength: int64(len(b.body)),
+			Request:       req,
+		}, nil
+	}
+}
+
+// FileResponseFunc is a round trip function that replies with the contents of a golden
+// file from disk, read fresh on every call, with the given content type.
+func FileResponseFunc(path, contentType string) func(*http.Request) (*http.Response, error) {
+	return func(req *http.Request) (*http.Response, error) {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		header := make(http.Header)
+		if contentType != "" {
+			header.Set("Content-Type", contentType)
+		}
+		header.Set("Content-Length", strconv.Itoa(len(data)))
+		return &http.Response{
+			StatusCode:    http.StatusOK,
+			Header:        header,
+			Body:          ioutil.NopCloser(bytes.NewReader(data)),
+			ContentLength: int64(len(data)),
+			Request:       req,
+		}, nil
+	}
+}
+
+// XMLResponseFunc is a round trip function that for request returns code and v marshalled as
+// XML, with the XML content header.
+func XMLResponseFunc(code int, v interface{}) func(*http.Request) (*http.Response, error) {
+	return func(req *http.Request) (*http.Response, error) {
+		body, err := xml.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+
+		header := make(http.Header)
+		header.Set("Content-Type", "application/xml")
+		header.Set("Content-Length", strconv.Itoa(len(body)))
+		return &http.Response{
+			StatusCode:    code,
+			Header:        header,
+			Body:          ioutil.NopCloser(bytes.NewReader(body)),
+			ContentLength: int64(len(body)),
+			Request:       req,
+		}, nil
+	}
+}
+
+// GzipResponseFunc is a round trip function that replies with data, transparently gzip
+// compressed, setting Content-Encoding accordingly. Useful for testing clients that are
+// expected to transparently decompress responses.
+func GzipResponseFunc(code int, data []byte) func(*http.Request) (*http.Response, error) {
+	return func(req *http.Request) (*http.Response, error) {
+		var buf bytes.Buffer
+		gz := gzip.NewWriter(&buf)
+		if _, err := gz.Write(data); err != nil {
+			return nil, err
+		}
+		if err := gz.Close(); err != nil {
+			return nil, err
+		}
+
+		header := make(http.Header)
+		header.Set("Content-Encoding", "gzip")
+		header.Set("Content-Length", strconv.Itoa(buf.Len()))
+		return &http.Response{
+			StatusCode:    code,
+			Header:        header,
+			Body:          ioutil.NopCloser(bytes.NewReader(buf.Bytes())),
+			ContentLength: int64(buf.Len()),
+			Request:       req,
+		}, nil
+	}
+}
+
+// ChunkedResponseFunc is a round trip function that writes chunks to the response body one
+// by one, sleeping delay between each, to simulate a slow or streaming response. The body
+// is backed by an io.Pipe, so reading it blocks until the next chunk is written.
+func ChunkedResponseFunc(code int, chunks [][]byte, delay time.Duration) func(*http.Request) (*http.Response, error) {
+	return func(req *http.Request) (*http.Response, error) {
+		pr, pw := io.Pipe()
+		go func() {
+			for i, chunk := range chunks {
+				if i > 0 && delay > 0 {
+					time.Sleep(delay)
+				}
+				if _, err := pw.Write(chunk); err != nil {
+					pw.CloseWithError(err)
+					return
+				}
+			}
+			pw.Close()
+		}()
+
+		return &http.Response{
+			StatusCode: code,
+			Body:       pr,
+			Request:    req,
+		}, nil
+	}
+}
+
+// RedirectResponseFunc is a round trip function that replies with a redirect to location.
+func RedirectResponseFunc(code int, location string) func(*http.Request) (*http.Response, error) {
+	return func(req *http.Request) (*http.Response, error) {
+		header := make(http.Header)
+		header.Set("Location", location)
+		return &http.Response{
+			StatusCode: code,
+			Header:     header,
+			Body:       ioutil.NopCloser(bytes.NewReader(nil)),
+			Request:    req,
+		}, nil
+	}
+}
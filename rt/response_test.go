@@ -0,0 +1,123 @@
+package rt
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/Bplotka/go-httpt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResponseBuilder_JSON(t *testing.T) {
+	r, err := http.NewRequest(string(httpt.GET), "/test/path", nil)
+	require.NoError(t, err)
+
+	s := httpt.NewRawServer()
+	s.Push(Response().Status(http.StatusCreated).Header("X-Foo", "bar").JSON(map[string]string{"error": "test_err"}).Build())
+	resp, err := s.HTTPClient().Do(r)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, "bar", resp.Header.Get("X-Foo"))
+	assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+	assert.Equal(t, r, resp.Request)
+
+	body, err := ioutil.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"error":"test_err"}`, string(body))
+}
+
+func TestFileResponseFunc(t *testing.T) {
+	r, err := http.NewRequest(string(httpt.GET), "/test/path", nil)
+	require.NoError(t, err)
+
+	f, err := ioutil.TempFile("", "httpt-file-response")
+	require.NoError(t, err)
+	defer os.Remove(f.Name())
+	_, err = f.WriteString("hello file")
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	s := httpt.NewRawServer()
+	s.Push(FileResponseFunc(f.Name(), "text/plain"))
+	resp, err := s.HTTPClient().Do(r)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/plain", resp.Header.Get("Content-Type"))
+	assert.Equal(t, "10", resp.Header.Get("Content-Length"))
+	body, err := ioutil.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, "hello file", string(body))
+}
+
+func TestXMLResponseFunc(t *testing.T) {
+	r, err := http.NewRequest(string(httpt.GET), "/test/path", nil)
+	require.NoError(t, err)
+
+	type payload struct {
+		Error string `xml:"error"`
+	}
+
+	s := httpt.NewRawServer()
+	s.Push(XMLResponseFunc(http.StatusOK, payload{Error: "test_err"}))
+	resp, err := s.HTTPClient().Do(r)
+	require.NoError(t, err)
+
+	assert.Equal(t, "application/xml", resp.Header.Get("Content-Type"))
+	body, err := ioutil.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.Contains(t, string(body), "<error>test_err</error>")
+}
+
+func TestGzipResponseFunc(t *testing.T) {
+	r, err := http.NewRequest(string(httpt.GET), "/test/path", nil)
+	require.NoError(t, err)
+
+	s := httpt.NewRawServer()
+	s.Push(GzipResponseFunc(http.StatusOK, []byte("hello gzip")))
+	resp, err := s.HTTPClient().Do(r)
+	require.NoError(t, err)
+
+	assert.Equal(t, "gzip", resp.Header.Get("Content-Encoding"))
+	gz, err := gzip.NewReader(resp.Body)
+	require.NoError(t, err)
+	body, err := ioutil.ReadAll(gz)
+	assert.NoError(t, err)
+	assert.Equal(t, "hello gzip", string(body))
+}
+
+func TestChunkedResponseFunc(t *testing.T) {
+	r, err := http.NewRequest(string(httpt.GET), "/test/path", nil)
+	require.NoError(t, err)
+
+	s := httpt.NewRawServer()
+	s.Push(ChunkedResponseFunc(http.StatusOK, [][]byte{[]byte("chunk1"), []byte("chunk2")}, time.Millisecond))
+	resp, err := s.HTTPClient().Do(r)
+	require.NoError(t, err)
+
+	body, err := ioutil.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, "chunk1chunk2", string(body))
+}
+
+func TestRedirectResponseFunc(t *testing.T) {
+	r, err := http.NewRequest(string(httpt.GET), "/test/path", nil)
+	require.NoError(t, err)
+
+	s := httpt.NewRawServer()
+	s.Push(RedirectResponseFunc(http.StatusFound, "/new/path"))
+
+	// Do the round trip directly: Client.Do would follow the redirect and re-issue
+	// the request against "/new/path", which isn't mocked here.
+	resp, err := s.HTTPClient().Transport.RoundTrip(r)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusFound, resp.StatusCode)
+	assert.Equal(t, "/new/path", resp.Header.Get("Location"))
+}
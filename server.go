@@ -6,6 +6,7 @@ import (
 	"io/ioutil"
 	"net/http"
 	"strings"
+	"sync"
 	"testing"
 )
 
@@ -48,18 +49,26 @@ type Server struct {
 	*tripBuilder
 
 	DefaultRoundTrip RoundTripFunc
+
+	// MaxBodyBytes caps how much of each request body is kept by the recorder
+	// (see Calls, CallsFor, LastCall). Zero means unlimited. It never affects
+	// what the matched RoundTripFunc sees. Note that the full body is still read
+	// into memory before truncation, so this does not bound memory usage for a
+	// large request body.
+	MaxBodyBytes int64
+
+	recorder *recorder
 }
 
 // NotMockedFunc is a round trip function that fails Go test. It is used if accidentally httpt.Server is used
 // but not round trip func was stacked.
-func NotMockedFunc(t *testing.T) func(*http.Request) (*http.Response, error) {
+func NotMockedFunc(t *testing.T, s *Server) func(*http.Request) (*http.Response, error) {
 	return func(r *http.Request) (*http.Response, error) {
-		msg := fmt.Sprintf("httpt.Server: RoundTripFunc not mocked for this request %s:%s",
-			r.Method, getPathOnly(r))
-		t.Errorf(msg)
+		err := newNotMockedError(s.engine.snapshot(), Method(r.Method), getPathOnly(r))
+		t.Errorf(err.Error())
 		return &http.Response{
 			StatusCode: http.StatusInternalServerError,
-			Body:       ioutil.NopCloser(bytes.NewBufferString(msg)),
+			Body:       ioutil.NopCloser(bytes.NewBufferString(err.Error())),
 		}, nil
 	}
 }
@@ -67,10 +76,12 @@ func NotMockedFunc(t *testing.T) func(*http.Request) (*http.Response, error) {
 // NewServer constructs Server with NotMockedFunc as default.
 // Always use that when running within go test.
 func NewServer(t *testing.T) *Server {
-	return &Server{
-		tripBuilder:      newTripBuilder(),
-		DefaultRoundTrip: NotMockedFunc(t),
+	s := &Server{
+		tripBuilder: newTripBuilder(),
+		recorder:    &recorder{},
 	}
+	s.DefaultRoundTrip = NotMockedFunc(t, s)
+	return s
 }
 
 // NewRawServer constructs Server without any default round trip function.
@@ -78,6 +89,7 @@ func NewServer(t *testing.T) *Server {
 func NewRawServer() *Server {
 	return &Server{
 		tripBuilder: newTripBuilder(),
+		recorder:    &recorder{},
 	}
 }
 
@@ -93,18 +105,30 @@ func (s *Server) Reset() {
 	s.engine.reset()
 }
 
-// Len returns number of round trip functions (requests) that are mocked.
+// Len returns number of one-shot round trip functions (requests) that are still expected.
+// Persistent round trips registered through Persist or Times are not counted, since they
+// are never "used up" the way one-shot ones are.
 // Useful example:
 //    assert.Equal(t, 0, s.Len()) // at the end of your unit test with httpt.Server, to check if all mocked requests were actually used.
 func (s *Server) Len() int {
-	return len(s.engine.queue)
+	n := 0
+	for _, e := range s.engine.snapshot() {
+		if !e.persistent {
+			n++
+		}
+	}
+	return n
 }
 
-// NotDoneRTs returns string slice with concatenated [METHOD]path for Round trips which are still expected. Useful when after test Len != 0.
+// StillExpectedRTs returns string slice with concatenated [METHOD]path for one-shot Round trips which
+// are still expected. Useful when after test Len != 0.
 func (s *Server) StillExpectedRTs() []string {
 	var out []string
-	for _, rt := range s.engine.queue {
-		out = append(out, fmt.Sprintf("[%s]%s", rt.method, rt.path))
+	for _, rt := range s.engine.snapshot() {
+		if rt.persistent {
+			continue
+		}
+		out = append(out, rt.String())
 	}
 	return out
 }
@@ -112,40 +136,137 @@ func (s *Server) StillExpectedRTs() []string {
 type tripEntry struct {
 	method Method
 	path   string
-	trip   RoundTripFunc
+
+	// matcher is set for entries pushed via OnMatch. When set, it takes
+	// over matching and scoring from method/path.
+	matcher *Matcher
+
+	trip RoundTripFunc
+
+	// persistent entries, registered through Persist or Times, are matched
+	// without being removed from the queue. remaining tracks how many times
+	// are left (-1 means unlimited) and is only meaningful when persistent.
+	persistent bool
+	remaining  int
+}
+
+// String returns the entry formatted as [METHOD]path, or [METHOD]<matcher> for
+// entries pushed via OnMatch which have no single path.
+func (e *tripEntry) String() string {
+	if e.matcher != nil {
+		return fmt.Sprintf("[%s]<matcher>", e.matcher.Method)
+	}
+	return fmt.Sprintf("[%s]%s", e.method, e.path)
 }
 
+// score reports how specific e is for req, or ok=false if e does not match req at all.
+// Higher score means more specific. "Most specific wins" only applies among entries pushed
+// via OnMatch: a classic On/Push entry always scores 0, so among themselves they stay in
+// strict FIFO order, same as before OnMatch existed.
+func (e *tripEntry) score(req *http.Request, method Method, path string) (score int, ok bool) {
+	if e.matcher != nil {
+		return e.matcher.score(req, path)
+	}
+
+	if e.method != method && e.method != ANY {
+		return 0, false
+	}
+	if e.path != path && e.path != AnyPath {
+		return 0, false
+	}
+	return 0, true
+}
+
+// tripQueue is safe for concurrent use, since a Server's mocked transport may be hit from
+// multiple goroutines at once (e.g. a component polling a persisted mock in the background).
 type tripQueue struct {
+	mu    sync.Mutex
 	queue []tripEntry
 }
 
-func (q *tripQueue) push(method Method, path string, r RoundTripFunc) {
+func (q *tripQueue) push(method Method, path string, r RoundTripFunc, persistent bool, remaining int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
 	q.queue = append(q.queue, tripEntry{
-		method: method,
-		path:   path,
-		trip:   r,
+		method:     method,
+		path:       path,
+		trip:       r,
+		persistent: persistent,
+		remaining:  remaining,
+	})
+}
+
+func (q *tripQueue) pushMatch(m Matcher, r RoundTripFunc, persistent bool, remaining int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.queue = append(q.queue, tripEntry{
+		matcher:    &m,
+		trip:       r,
+		persistent: persistent,
+		remaining:  remaining,
 	})
 }
 
 func (q *tripQueue) reset() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
 	q.queue = []tripEntry(nil)
 }
 
-func (q *tripQueue) pop(method Method, path string) (RoundTripFunc, bool) {
+// snapshot returns a copy of the queue as it stands right now, safe to range over without
+// holding q.mu.
+func (q *tripQueue) snapshot() []tripEntry {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	out := make([]tripEntry, len(q.queue))
+	copy(out, q.queue)
+	return out
+}
+
+// pop removes and returns the most specific entry matching req, preferring
+// the earliest pushed entry (FIFO) among entries of equal specificity, and
+// one-shot entries over persistent ones of otherwise equal specificity (so
+// tests can still override a persistent mock for a single call).
+func (q *tripQueue) pop(req *http.Request, method Method, path string) (RoundTripFunc, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	best := -1
+	bestRank := -1
 	for i, e := range q.queue {
-		if e.method != method && e.method != ANY {
+		score, ok := e.score(req, method, path)
+		if !ok {
 			continue
 		}
-
-		if e.path != path && e.path != AnyPath {
-			continue
+		rank := score * 2
+		if !e.persistent {
+			rank++
+		}
+		if rank > bestRank {
+			bestRank = rank
+			best = i
 		}
+	}
+
+	if best == -1 {
+		return nil, false
+	}
 
-		q.queue = append(q.queue[:i], q.queue[i+1:]...)
-		return e.trip, true
+	e := &q.queue[best]
+	trip := e.trip
+	if e.persistent {
+		if e.remaining > 0 {
+			e.remaining--
+			if e.remaining == 0 {
+				q.queue = append(q.queue[:best], q.queue[best+1:]...)
+			}
+		}
+		return trip, true
 	}
 
-	return nil, false
+	q.queue = append(q.queue[:best], q.queue[best+1:]...)
+	return trip, true
 }
 
 func getPathOnly(req *http.Request) string {
@@ -160,6 +281,10 @@ type tripPusher struct {
 	engine *tripQueue
 	method Method
 	path   string
+
+	// matcher is set when the pusher was created via OnMatch, in which case
+	// Push registers matcher instead of method/path.
+	matcher *Matcher
 }
 
 func newTripPusher(engine *tripQueue, method Method, path string) *tripPusher {
@@ -170,11 +295,47 @@ func newTripPusher(engine *tripQueue, method Method, path string) *tripPusher {
 	}
 }
 
+func newMatchTripPusher(engine *tripQueue, m Matcher) *tripPusher {
+	return &tripPusher{
+		engine:  engine,
+		matcher: &m,
+	}
+}
+
 // Push adds round trip function to the queue.
 // Queue logic is in single-shot FIFO manner. You need to add round trip for EVERY call made by this transport.
-// Round trips are performed in FIFO order including first matching round trip.
+// Round trips are performed in FIFO order, including first matching round trip; entries
+// pushed via OnMatch are the exception, since a Matcher's scoring lets a more specific one
+// win over an earlier, less specific one (see Matcher).
 func (t *tripPusher) Push(f RoundTripFunc) {
-	t.engine.push(t.method, t.path, f)
+	t.push(f, false, 0)
+}
+
+// Persist adds a round trip function that stays in the queue and keeps matching
+// indefinitely, instead of being removed after the first match. Useful for mocking
+// endpoints that are hit an unknown number of times, e.g. health checks or polling loops.
+// A one-shot entry of equal specificity (pushed via Push) still takes priority,
+// so tests can override a persisted mock for a single call.
+func (t *tripPusher) Persist(f RoundTripFunc) {
+	t.push(f, true, -1)
+}
+
+// Times adds a round trip function that matches up to n times before being removed from
+// the queue, similar to Persist but bounded. n must be positive; Times(n, f) for n <= 0
+// adds nothing, so the entry never matches.
+func (t *tripPusher) Times(n int, f RoundTripFunc) {
+	if n <= 0 {
+		return
+	}
+	t.push(f, true, n)
+}
+
+func (t *tripPusher) push(f RoundTripFunc, persistent bool, remaining int) {
+	if t.matcher != nil {
+		t.engine.pushMatch(*t.matcher, f, persistent, remaining)
+		return
+	}
+	t.engine.push(t.method, t.path, f, persistent, remaining)
 }
 
 type tripBuilder struct {
@@ -211,13 +372,16 @@ func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
 	method := Method(req.Method)
 	path := getPathOnly(req)
 
-	if r, ok := t.s.engine.pop(method, path); ok {
+	if err := t.s.recorder.record(req, t.s.MaxBodyBytes); err != nil {
+		return nil, err
+	}
+
+	if r, ok := t.s.engine.pop(req, method, path); ok {
 		return r(req)
 	}
 
 	if t.s.DefaultRoundTrip == nil {
-		return nil, fmt.Errorf(
-			"httpt.Server request not mocked for this request %s:%s", method, path)
+		return nil, newNotMockedError(t.s.engine.snapshot(), method, path)
 	}
 	return t.s.DefaultRoundTrip(req)
 }
@@ -198,6 +198,23 @@ func TestServer_RightOrder(t *testing.T) {
 	require.Error(t, err)
 }
 
+func TestServer_RightOrder_CatchAllBeforeSpecific(t *testing.T) {
+	r, err := http.NewRequest(string(GET), "/orders", nil)
+	require.NoError(t, err)
+
+	s := NewRawServer()
+	// A classic catch-all pushed before a more specific classic entry still wins: Matcher's
+	// "most specific wins" scoring only applies to entries pushed via OnMatch.
+	s.On(ANY, AnyPath).Push(mockedRT)
+	s.On(GET, "/orders").Push(specificRT)
+
+	resp, err := s.HTTPClient().Do(r)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	assert.Equal(t, 1, s.Len())
+}
+
 func TestServer_RightOrderWithReset(t *testing.T) {
 	r, err := http.NewRequest(string(GET), "/test/path", nil)
 	require.NoError(t, err)